@@ -1,6 +1,7 @@
 package oauth1
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
@@ -23,6 +24,39 @@ type Config struct {
 	CallbackURL string
 	// Provider Endpoint specifying OAuth1 endpoint URLs
 	Endpoint Endpoint
+	// SignatureMethod determines how requests are signed. If nil, requests
+	// are signed with HMAC-SHA1 using ConsumerSecret, matching the RFC 5849
+	// default. Set this to use a different method, e.g. RSA-SHA1 for
+	// providers such as Atlassian Stash/Bitbucket Server that authenticate
+	// consumers via a private key instead of a shared secret.
+	SignatureMethod SignatureMethod
+	// IncludeBodyHash enables the OAuth Request Body Hash extension: a
+	// non-form, non-GET/HEAD request body is hashed into oauth_body_hash and
+	// included in the signature base, as required by providers such as
+	// Twitter's media upload endpoint or Trello/Xero APIs.
+	IncludeBodyHash bool
+	// HTTPClient is used to perform the RequestToken and AccessToken
+	// exchanges. If nil, http.DefaultClient is used. Set this to inject a
+	// proxy, custom TLS config, instrumentation, or a test server's client.
+	HTTPClient *http.Client
+}
+
+// httpClient returns the Config's HTTPClient, defaulting to
+// http.DefaultClient when none is set.
+func (c *Config) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// signatureMethod returns the Config's SignatureMethod, defaulting to
+// HMAC-SHA1 with ConsumerSecret when none is set.
+func (c *Config) signatureMethod() SignatureMethod {
+	if c.SignatureMethod != nil {
+		return c.SignatureMethod
+	}
+	return NewHMACSHA1Signer(c.ConsumerSecret)
 }
 
 // NewConfig returns a new Config with the given consumer key and secret.
@@ -47,6 +81,29 @@ func NewClient(config *Config, token *Token) *http.Client {
 	return &http.Client{Transport: transport}
 }
 
+// TwoLeggedClient returns an HTTP client which signs every request using
+// only the consumer credentials (two-legged OAuth), without obtaining an
+// access token. If requestorID is non-empty, it is sent as the
+// xoauth_requestor_id parameter, as used by providers such as Google Apps
+// and Yahoo BOSS to identify the resource owner on whose behalf the
+// consumer is acting.
+//
+// requestorID is taken here per client, not per request, mirroring Client's
+// access Token: a *http.Client is normally built once and reused for many
+// calls acting on behalf of the same resource owner, so binding it at
+// construction avoids threading it through every call site. Callers acting
+// on behalf of different resource owners should build a separate
+// TwoLeggedClient per requestorID (or call Signer.SetTwoLeggedAuthHeader
+// directly if the requestor varies per request).
+func (c *Config) TwoLeggedClient(requestorID string) *http.Client {
+	return &http.Client{
+		Transport: &twoLeggedTransport{
+			signer:      &Signer{config: c, clock: newRealClock()},
+			requestorID: requestorID,
+		},
+	}
+}
+
 // RequestToken obtains a Request token and secret (temporary credential) by
 // POSTing a request (with oauth_callback in the auth header) to the Endpoint
 // RequestTokenURL. The response body form is validated to ensure
@@ -54,13 +111,19 @@ func NewClient(config *Config, token *Token) *http.Client {
 // (temporary credentials).
 // See RFC 5849 2.1 Temporary Credentials.
 func (c *Config) RequestToken() (requestToken, requestSecret string, err error) {
-	req, err := http.NewRequest("POST", c.Endpoint.RequestTokenURL, nil)
+	return c.RequestTokenContext(context.Background())
+}
+
+// RequestTokenContext is the context-aware version of RequestToken. The
+// request is cancelled if ctx is done before the exchange completes.
+func (c *Config) RequestTokenContext(ctx context.Context) (requestToken, requestSecret string, err error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.Endpoint.RequestTokenURL, nil)
 	if err != nil {
 		return "", "", err
 	}
 	signer := &Signer{config: c, clock: newRealClock()}
 	signer.SetRequestTokenAuthHeader(req)
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
 		return "", "", err
 	}
@@ -126,13 +189,19 @@ func (c *Config) HandleAuthorizationCallback(req *http.Request) (requestToken, v
 // credentials).
 // See RFC 5849 2.3 Token Credentials.
 func (c *Config) AccessToken(requestToken, requestSecret, verifier string) (accessToken, accessSecret string, err error) {
-	req, err := http.NewRequest("POST", c.Endpoint.AccessTokenURL, nil)
+	return c.AccessTokenContext(context.Background(), requestToken, requestSecret, verifier)
+}
+
+// AccessTokenContext is the context-aware version of AccessToken. The
+// request is cancelled if ctx is done before the exchange completes.
+func (c *Config) AccessTokenContext(ctx context.Context, requestToken, requestSecret, verifier string) (accessToken, accessSecret string, err error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.Endpoint.AccessTokenURL, nil)
 	if err != nil {
 		return "", "", err
 	}
 	signer := &Signer{config: c, clock: newRealClock()}
 	signer.SetAccessTokenAuthHeader(req, requestToken, requestSecret, verifier)
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
 		return "", "", err
 	}
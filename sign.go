@@ -2,11 +2,12 @@ package oauth1
 
 import (
 	"bytes"
-	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
+	"hash"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -28,7 +29,8 @@ const (
 	oauthVersionParam         = "oauth_version"
 	oauthCallbackParam        = "oauth_callback"
 	oauthVerifierParam        = "oauth_verifier"
-	defaultSignatureMethod    = "HMAC-SHA1"
+	oauthBodyHashParam        = "oauth_body_hash"
+	xoauthRequestorIDParam    = "xoauth_requestor_id"
 	defaultOauthVersion       = "1.0"
 	contentType               = "Content-Type"
 	formContentType           = "application/x-www-form-urlencoded"
@@ -51,7 +53,10 @@ func (s *Signer) SetRequestTokenAuthHeader(req *http.Request) error {
 		return err
 	}
 	signatureBase := signatureBase(req, params)
-	signature := signature(s.config.ConsumerSecret, "", signatureBase)
+	signature, err := s.config.signatureMethod().Sign("", signatureBase)
+	if err != nil {
+		return err
+	}
 	oauthParams[oauthSignatureParam] = signature
 	setAuthorizationHeader(req, authHeaderValue(oauthParams))
 	return nil
@@ -68,7 +73,10 @@ func (s *Signer) SetAccessTokenAuthHeader(req *http.Request, requestToken, reque
 		return err
 	}
 	signatureBase := signatureBase(req, params)
-	signature := signature(s.config.ConsumerSecret, requestSecret, signatureBase)
+	signature, err := s.config.signatureMethod().Sign(requestSecret, signatureBase)
+	if err != nil {
+		return err
+	}
 	oauthParams[oauthSignatureParam] = signature
 	setAuthorizationHeader(req, authHeaderValue(oauthParams))
 	return nil
@@ -79,12 +87,58 @@ func (s *Signer) SetAccessTokenAuthHeader(req *http.Request, requestToken, reque
 func (s *Signer) SetRequestAuthHeader(req *http.Request, accessToken *Token) error {
 	oauthParams := s.commonOAuthParams()
 	oauthParams[oauthTokenParam] = accessToken.Token
+	if s.config.IncludeBodyHash {
+		bodyHash, err := s.bodyHash(req)
+		if err != nil {
+			return err
+		}
+		if bodyHash != "" {
+			oauthParams[oauthBodyHashParam] = bodyHash
+		}
+	}
+	params, err := collectParameters(req, oauthParams)
+	if err != nil {
+		return err
+	}
+	signatureBase := signatureBase(req, params)
+	signature, err := s.config.signatureMethod().Sign(accessToken.TokenSecret, signatureBase)
+	if err != nil {
+		return err
+	}
+	oauthParams[oauthSignatureParam] = signature
+	setAuthorizationHeader(req, authHeaderValue(oauthParams))
+	return nil
+}
+
+// SetTwoLeggedAuthHeader sets the OAuth1 header for a two-legged
+// (consumer-only) request: oauth_token is omitted and the request is signed
+// with an empty token secret. If requestorID is non-empty, it is added as
+// xoauth_requestor_id so it participates in the signature base and the
+// Authorization header, per the 2-legged OAuth pattern used by providers
+// such as Google Apps and Yahoo BOSS.
+func (s *Signer) SetTwoLeggedAuthHeader(req *http.Request, requestorID string) error {
+	oauthParams := s.commonOAuthParams()
+	if requestorID != "" {
+		oauthParams[xoauthRequestorIDParam] = requestorID
+	}
+	if s.config.IncludeBodyHash {
+		bodyHash, err := s.bodyHash(req)
+		if err != nil {
+			return err
+		}
+		if bodyHash != "" {
+			oauthParams[oauthBodyHashParam] = bodyHash
+		}
+	}
 	params, err := collectParameters(req, oauthParams)
 	if err != nil {
 		return err
 	}
 	signatureBase := signatureBase(req, params)
-	signature := signature(s.config.ConsumerSecret, accessToken.TokenSecret, signatureBase)
+	signature, err := s.config.signatureMethod().Sign("", signatureBase)
+	if err != nil {
+		return err
+	}
 	oauthParams[oauthSignatureParam] = signature
 	setAuthorizationHeader(req, authHeaderValue(oauthParams))
 	return nil
@@ -95,7 +149,7 @@ func (s *Signer) SetRequestAuthHeader(req *http.Request, accessToken *Token) err
 func (s *Signer) commonOAuthParams() map[string]string {
 	return map[string]string{
 		oauthConsumerKeyParam:     s.config.ConsumerKey,
-		oauthSignatureMethodParam: defaultSignatureMethod,
+		oauthSignatureMethodParam: s.config.signatureMethod().Name(),
 		oauthTimestampParam:       strconv.FormatInt(s.epoch(), 10),
 		oauthNonceParam:           s.nonce(),
 		oauthVersionParam:         defaultOauthVersion,
@@ -162,17 +216,35 @@ func sortParameters(params map[string]string) []string {
 	return pairs
 }
 
+// parameter is a single key/value pair collected from a request's query,
+// form body, or OAuth protocol parameters. A []parameter is used instead of
+// a map so that repeated keys (e.g. a query string with two "tag" values)
+// are all preserved, as RFC 5849 3.4.1.3 requires every occurrence of a
+// parameter to contribute to the signature base.
+type parameter struct {
+	Key   string
+	Value string
+}
+
 // collectParameters collects request parameters from the request query, OAuth
 // parameters (which should exclude oauth_signature), and the request body
 // provided the body is single part, form encoded, and the form content type
-// header is set. The returned map of collected parameter keys and values
-// follow RFC 5849 3.4.1.3, except duplicate parameters are not supported.
-func collectParameters(req *http.Request, oauthParams map[string]string) (map[string]string, error) {
+// header is set. The returned parameters follow RFC 5849 3.4.1.3, including
+// every value of a repeated query or form key. oauth_signature is always
+// excluded per 3.4.1.3.1, even if present in the query or body, since a
+// Verifier reconstructing the signature base for a request that delivered
+// its oauth_* parameters via the query or body (3.5.2/3.5.3) will find
+// oauth_signature there too.
+func collectParameters(req *http.Request, oauthParams map[string]string) ([]parameter, error) {
 	// add oauth, query, and body parameters into params
-	params := map[string]string{}
-	for key, value := range req.URL.Query() {
-		// most backends do not accept duplicate query keys
-		params[key] = value[0]
+	var params []parameter
+	for key, values := range req.URL.Query() {
+		if key == oauthSignatureParam {
+			continue
+		}
+		for _, value := range values {
+			params = append(params, parameter{key, value})
+		}
 	}
 	if req.Body != nil && req.Header.Get(contentType) == formContentType {
 		// reads data to a []byte, draining req.Body
@@ -184,23 +256,65 @@ func collectParameters(req *http.Request, oauthParams map[string]string) (map[st
 		if err != nil {
 			return nil, err
 		}
-		for key, value := range values {
-			// not supporting params with duplicate keys
-			params[key] = value[0]
+		for key, vals := range values {
+			if key == oauthSignatureParam {
+				continue
+			}
+			for _, value := range vals {
+				params = append(params, parameter{key, value})
+			}
 		}
 		// reinitialize Body with ReadCloser over the []byte
 		req.Body = ioutil.NopCloser(bytes.NewReader(b))
 	}
 	for key, value := range oauthParams {
-		params[key] = value
+		params = append(params, parameter{key, value})
 	}
 	return params, nil
 }
 
+// bodyHash implements the OAuth Request Body Hash extension for signing. See
+// bodyHashForRequest.
+func (s *Signer) bodyHash(req *http.Request) (string, error) {
+	return bodyHashForRequest(req, s.config.signatureMethod())
+}
+
+// bodyHashForRequest computes the OAuth Request Body Hash extension value
+// for req: for requests whose body is present, not form-encoded, and whose
+// method is not GET or HEAD, it returns the base64 encoded hash of the body
+// (SHA1, or SHA256 when method is a SHA256 variant). Returns an empty string
+// when no body hash applies. The body is buffered and restored on req.Body,
+// mirroring collectParameters' handling of form bodies. Used both to sign
+// outgoing requests and, independently, by Verifier to check that an
+// incoming request's declared oauth_body_hash matches its actual body.
+func bodyHashForRequest(req *http.Request, method SignatureMethod) (string, error) {
+	httpMethod := strings.ToUpper(req.Method)
+	if httpMethod == "GET" || httpMethod == "HEAD" {
+		return "", nil
+	}
+	if req.Body == nil || req.Header.Get(contentType) == formContentType {
+		return "", nil
+	}
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return "", err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	var h hash.Hash
+	switch method.Name() {
+	case HMACSHA256, RSASHA256:
+		h = sha256.New()
+	default:
+		h = sha1.New()
+	}
+	h.Write(b)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
 // signatureBase combines the uppercase request method, percent encoded base
 // string URI, and normalizes the request parameters int a parameter string.
 // Returns the OAuth1 signature base string according to RFC5849 3.4.1.
-func signatureBase(req *http.Request, params map[string]string) string {
+func signatureBase(req *http.Request, params []parameter) string {
 	method := strings.ToUpper(req.Method)
 	baseURL := baseURI(req)
 	parameterString := normalizedParameterString(params)
@@ -228,23 +342,27 @@ func baseURI(req *http.Request) string {
 	return fmt.Sprintf("%v://%v%v", scheme, host, path)
 }
 
-// parameterString normalizes collected OAuth parameters (which should exclude
-// oauth_signature) into a parameter string as defined in RFC 5894 3.4.1.3.2.
-// The parameters are encoded, sorted by key, keys and values joined with "&",
-// and pairs joined with "=" (e.g. foo=bar&q=gopher).
-func normalizedParameterString(params map[string]string) string {
-	return strings.Join(sortParameters(encodeParameters(params)), "&")
-}
-
-// signature creates a signing key from the consumer and token secrets and
-// calculates the HMAC signature bytes of the message using the SHA1 hash.
-// Returns the base64 encoded signature.
-func signature(consumerSecret, tokenSecret, message string) string {
-	signingKey := strings.Join([]string{consumerSecret, tokenSecret}, "&")
-	mac := hmac.New(sha1.New, []byte(signingKey))
-	mac.Write([]byte(message))
-	signatureBytes := mac.Sum(nil)
-	return base64.StdEncoding.EncodeToString(signatureBytes)
+// normalizedParameterString normalizes collected parameters (which should
+// exclude oauth_signature) into a parameter string as defined in RFC 5849
+// 3.4.1.3.2. Parameters are percent encoded, then sorted lexicographically
+// by encoded key and, for duplicate keys, by encoded value, preserving every
+// repeated key; pairs are joined with "=" and "&" (e.g. foo=bar&tag=a&tag=b).
+func normalizedParameterString(params []parameter) string {
+	encoded := make([]parameter, len(params))
+	for i, p := range params {
+		encoded[i] = parameter{PercentEncode(p.Key), PercentEncode(p.Value)}
+	}
+	sort.Slice(encoded, func(i, j int) bool {
+		if encoded[i].Key != encoded[j].Key {
+			return encoded[i].Key < encoded[j].Key
+		}
+		return encoded[i].Value < encoded[j].Value
+	})
+	pairs := make([]string, len(encoded))
+	for i, p := range encoded {
+		pairs[i] = fmt.Sprintf("%s=%s", p.Key, p.Value)
+	}
+	return strings.Join(pairs, "&")
 }
 
 // clock provides a interface for current time providers. A Clock can be used
@@ -0,0 +1,40 @@
+package oauth1
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestNormalizedParameterStringDuplicateKeys guards against a regression of
+// the bug described in RFC 5849 3.4.1.3: every occurrence of a repeated query
+// or form key must contribute its own pair to the parameter string, sorted
+// lexicographically by encoded key and then encoded value, not collapsed to
+// a single value as a map-based implementation would.
+func TestNormalizedParameterStringDuplicateKeys(t *testing.T) {
+	params := []parameter{
+		{"b", "2"},
+		{"a", "1"},
+		{"a", "2"},
+	}
+	got := normalizedParameterString(params)
+	want := "a=1&a=2&b=2"
+	if got != want {
+		t.Fatalf("normalizedParameterString() = %q, want %q", got, want)
+	}
+}
+
+func TestCollectParametersDuplicateQueryKeys(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/resource?tag=a&tag=b", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := collectParameters(req, map[string]string{"oauth_nonce": "n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := normalizedParameterString(params)
+	want := "oauth_nonce=n&tag=a&tag=b"
+	if got != want {
+		t.Fatalf("normalizedParameterString() = %q, want %q", got, want)
+	}
+}
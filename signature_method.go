@@ -0,0 +1,192 @@
+package oauth1
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"hash"
+)
+
+// Signature method names sent as the oauth_signature_method parameter,
+// as defined by RFC 5849 3.4 and the OAuth Core 1.0a RSA-SHA1 extension.
+const (
+	HMACSHA1   = "HMAC-SHA1"
+	HMACSHA256 = "HMAC-SHA256"
+	RSASHA1    = "RSA-SHA1"
+	RSASHA256  = "RSA-SHA256"
+	PLAINTEXT  = "PLAINTEXT"
+)
+
+// errSignatureMismatch is returned by a SignatureMethod's Verify when the
+// given signature does not match the expected one.
+var errSignatureMismatch = errors.New("oauth1: signature mismatch")
+
+// SignatureMethod signs and verifies OAuth1 signature base strings,
+// determining both the value sent as oauth_signature_method and how
+// oauth_signature is produced and checked. See RFC 5849 3.4.
+type SignatureMethod interface {
+	// Name returns the oauth_signature_method value identifying this method.
+	Name() string
+	// Sign returns the oauth_signature for message (base64 encoded for the
+	// HMAC and RSA methods; PLAINTEXT ignores message). tokenSecret is
+	// ignored by signature methods which do not use it (e.g. RSA-SHA1).
+	Sign(tokenSecret, message string) (string, error)
+	// Verify checks that signature is the correct signature of message,
+	// returning errSignatureMismatch (or a wrapped variant) if it is not.
+	Verify(tokenSecret, message, signature string) error
+}
+
+// hmacSignatureMethod implements the HMAC-SHA1 and HMAC-SHA256 signature
+// methods. The signing key is the concatenation of the percent encoded
+// consumer secret and token secret, joined by "&", per RFC 5849 3.4.2.
+type hmacSignatureMethod struct {
+	name           string
+	consumerSecret string
+	hash           func() hash.Hash
+}
+
+// NewHMACSHA1Signer returns a SignatureMethod implementing HMAC-SHA1 with the
+// given consumer secret.
+func NewHMACSHA1Signer(consumerSecret string) SignatureMethod {
+	return &hmacSignatureMethod{name: HMACSHA1, consumerSecret: consumerSecret, hash: sha1.New}
+}
+
+// NewHMACSHA256Signer returns a SignatureMethod implementing HMAC-SHA256 with
+// the given consumer secret.
+func NewHMACSHA256Signer(consumerSecret string) SignatureMethod {
+	return &hmacSignatureMethod{name: HMACSHA256, consumerSecret: consumerSecret, hash: sha256.New}
+}
+
+func (m *hmacSignatureMethod) Name() string {
+	return m.name
+}
+
+func (m *hmacSignatureMethod) Sign(tokenSecret, message string) (string, error) {
+	signingKey := PercentEncode(m.consumerSecret) + "&" + PercentEncode(tokenSecret)
+	mac := hmac.New(m.hash, []byte(signingKey))
+	mac.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (m *hmacSignatureMethod) Verify(tokenSecret, message, signature string) error {
+	want, err := m.Sign(tokenSecret, message)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(want), []byte(signature)) != 1 {
+		return errSignatureMismatch
+	}
+	return nil
+}
+
+// rsaSignatureMethod implements the RSA-SHA1 and RSA-SHA256 signature
+// methods described by the OAuth Core 1.0a RSA-SHA1 extension. The token
+// secret is ignored; the message is signed with the consumer's RSA private
+// key instead.
+type rsaSignatureMethod struct {
+	name       string
+	privateKey *rsa.PrivateKey
+	hash       crypto.Hash
+}
+
+// NewRSASHA1Signer returns a SignatureMethod implementing RSA-SHA1 with the
+// given consumer private key.
+func NewRSASHA1Signer(privateKey *rsa.PrivateKey) SignatureMethod {
+	return &rsaSignatureMethod{name: RSASHA1, privateKey: privateKey, hash: crypto.SHA1}
+}
+
+// NewRSASHA256Signer returns a SignatureMethod implementing RSA-SHA256 with
+// the given consumer private key.
+func NewRSASHA256Signer(privateKey *rsa.PrivateKey) SignatureMethod {
+	return &rsaSignatureMethod{name: RSASHA256, privateKey: privateKey, hash: crypto.SHA256}
+}
+
+func (m *rsaSignatureMethod) Name() string {
+	return m.name
+}
+
+func (m *rsaSignatureMethod) Sign(tokenSecret, message string) (string, error) {
+	hashed := m.hashed(message)
+	signed, err := rsa.SignPKCS1v15(rand.Reader, m.privateKey, m.hash, hashed)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signed), nil
+}
+
+func (m *rsaSignatureMethod) Verify(tokenSecret, message, signature string) error {
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return errSignatureMismatch
+	}
+	if err := rsa.VerifyPKCS1v15(&m.privateKey.PublicKey, m.hash, m.hashed(message), decoded); err != nil {
+		return errSignatureMismatch
+	}
+	return nil
+}
+
+func (m *rsaSignatureMethod) hashed(message string) []byte {
+	h := m.hash.New()
+	h.Write([]byte(message))
+	return h.Sum(nil)
+}
+
+// plaintextSignatureMethod implements the PLAINTEXT signature method
+// described by RFC 5849 3.4.4. The signature is the percent encoded
+// consumer and token secrets joined by "&"; no signature base is involved.
+type plaintextSignatureMethod struct {
+	consumerSecret string
+}
+
+// NewPlaintextSigner returns a SignatureMethod implementing PLAINTEXT with
+// the given consumer secret.
+func NewPlaintextSigner(consumerSecret string) SignatureMethod {
+	return &plaintextSignatureMethod{consumerSecret: consumerSecret}
+}
+
+func (m *plaintextSignatureMethod) Name() string {
+	return PLAINTEXT
+}
+
+func (m *plaintextSignatureMethod) Sign(tokenSecret, message string) (string, error) {
+	return PercentEncode(m.consumerSecret) + "&" + PercentEncode(tokenSecret), nil
+}
+
+func (m *plaintextSignatureMethod) Verify(tokenSecret, message, signature string) error {
+	want, _ := m.Sign(tokenSecret, message)
+	if subtle.ConstantTimeCompare([]byte(want), []byte(signature)) != 1 {
+		return errSignatureMismatch
+	}
+	return nil
+}
+
+// RSAPrivateKeyFromPEM parses a PEM encoded PKCS#1 or PKCS#8 RSA private key,
+// as needed to construct an RSA-SHA1 or RSA-SHA256 SignatureMethod, e.g. for
+// providers such as Atlassian Stash/Bitbucket Server which authenticate
+// consumers via RSA-SHA1 with an empty consumer secret.
+func RSAPrivateKeyFromPEM(pemData []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("oauth1: no PEM block found in private key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("oauth1: PEM private key is not an RSA key")
+	}
+	return key, nil
+}
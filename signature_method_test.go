@@ -0,0 +1,152 @@
+package oauth1
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestHMACSHA1SignerRoundTrip(t *testing.T) {
+	method := NewHMACSHA1Signer("consumer-secret")
+	if method.Name() != HMACSHA1 {
+		t.Fatalf("Name() = %q, want %q", method.Name(), HMACSHA1)
+	}
+	sig, err := method.Sign("token-secret", "base string")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := method.Verify("token-secret", "base string", sig); err != nil {
+		t.Fatalf("Verify() = %v, want nil", err)
+	}
+	if err := method.Verify("token-secret", "base string", sig+"x"); err == nil {
+		t.Fatal("Verify() = nil for a tampered signature, want error")
+	}
+	if err := method.Verify("wrong-token-secret", "base string", sig); err == nil {
+		t.Fatal("Verify() = nil for the wrong token secret, want error")
+	}
+}
+
+func TestHMACSHA256SignerRoundTrip(t *testing.T) {
+	method := NewHMACSHA256Signer("consumer-secret")
+	if method.Name() != HMACSHA256 {
+		t.Fatalf("Name() = %q, want %q", method.Name(), HMACSHA256)
+	}
+	sig, err := method.Sign("token-secret", "base string")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := method.Verify("token-secret", "base string", sig); err != nil {
+		t.Fatalf("Verify() = %v, want nil", err)
+	}
+	if err := method.Verify("token-secret", "a different base string", sig); err == nil {
+		t.Fatal("Verify() = nil for a mismatched base string, want error")
+	}
+}
+
+func TestPlaintextSignerRoundTrip(t *testing.T) {
+	method := NewPlaintextSigner("consumer-secret")
+	sig, err := method.Sign("token-secret", "ignored base string")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PercentEncode("consumer-secret") + "&" + PercentEncode("token-secret")
+	if sig != want {
+		t.Fatalf("Sign() = %q, want %q", sig, want)
+	}
+	// PLAINTEXT ignores the message entirely.
+	sig2, err := method.Sign("token-secret", "a completely different base string")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig2 != sig {
+		t.Fatalf("Sign() changed with the message: %q != %q", sig2, sig)
+	}
+	if err := method.Verify("token-secret", "ignored base string", sig); err != nil {
+		t.Fatalf("Verify() = %v, want nil", err)
+	}
+	if err := method.Verify("other-secret", "ignored base string", sig); err == nil {
+		t.Fatal("Verify() = nil for the wrong token secret, want error")
+	}
+}
+
+func TestRSASHA1SignerRoundTrip(t *testing.T) {
+	key := generateTestRSAKey(t)
+	method := NewRSASHA1Signer(key)
+	if method.Name() != RSASHA1 {
+		t.Fatalf("Name() = %q, want %q", method.Name(), RSASHA1)
+	}
+	// tokenSecret is ignored for RSA.
+	sig, err := method.Sign("ignored-token-secret", "base string")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := method.Verify("a-different-ignored-secret", "base string", sig); err != nil {
+		t.Fatalf("Verify() = %v, want nil", err)
+	}
+	if err := method.Verify("", "a different base string", sig); err == nil {
+		t.Fatal("Verify() = nil for a mismatched base string, want error")
+	}
+}
+
+func TestRSASHA256SignerRoundTrip(t *testing.T) {
+	key := generateTestRSAKey(t)
+	method := NewRSASHA256Signer(key)
+	if method.Name() != RSASHA256 {
+		t.Fatalf("Name() = %q, want %q", method.Name(), RSASHA256)
+	}
+	sig, err := method.Sign("", "base string")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := method.Verify("", "base string", sig); err != nil {
+		t.Fatalf("Verify() = %v, want nil", err)
+	}
+	otherKey := generateTestRSAKey(t)
+	if err := NewRSASHA256Signer(otherKey).Verify("", "base string", sig); err == nil {
+		t.Fatal("Verify() = nil for a signature from a different key, want error")
+	}
+}
+
+func TestRSAPrivateKeyFromPEM(t *testing.T) {
+	key := generateTestRSAKey(t)
+
+	pkcs1PEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	parsed, err := RSAPrivateKeyFromPEM(pkcs1PEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.N.Cmp(key.N) != 0 {
+		t.Fatal("PKCS1: parsed key does not match the original key")
+	}
+
+	pkcs8Bytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8PEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8Bytes})
+	parsed, err = RSAPrivateKeyFromPEM(pkcs8PEM)
+	if err != nil {
+		t.Fatalf("RSAPrivateKeyFromPEM() on a PKCS8 key = %v, want nil", err)
+	}
+	if parsed.N.Cmp(key.N) != 0 {
+		t.Fatal("PKCS8: parsed key does not match the original key")
+	}
+
+	if _, err := RSAPrivateKeyFromPEM([]byte("not a pem block")); err == nil {
+		t.Fatal("RSAPrivateKeyFromPEM() on garbage input = nil error, want error")
+	}
+}
+
+func generateTestRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() = %v", err)
+	}
+	return key
+}
@@ -0,0 +1,28 @@
+package oauth1
+
+import "net/http"
+
+// twoLeggedTransport is an http.RoundTripper that signs every request for
+// two-legged (consumer-only) OAuth, as returned by Config.TwoLeggedClient.
+type twoLeggedTransport struct {
+	Base        http.RoundTripper
+	signer      *Signer
+	requestorID string
+}
+
+// RoundTrip authorizes the request with a two-legged OAuth1 header and
+// delegates to the underlying RoundTripper.
+func (t *twoLeggedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req2 := req.Clone(req.Context())
+	if err := t.signer.SetTwoLeggedAuthHeader(req2, t.requestorID); err != nil {
+		return nil, err
+	}
+	return t.base().RoundTrip(req2)
+}
+
+func (t *twoLeggedTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
@@ -0,0 +1,278 @@
+package oauth1
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TokenLookup resolves the consumer and token secrets for a consumer key and
+// token presented on an incoming request, so that Verifier can recompute the
+// expected signature. Returning an error indicates the consumer key or token
+// is unknown.
+type TokenLookup func(consumerKey, token string) (consumerSecret, tokenSecret string, err error)
+
+// NonceStore tracks nonces seen for a consumer key so that Verifier can
+// reject replayed requests per RFC 5849 3.3.
+type NonceStore interface {
+	// Seen records that nonce was used by consumerKey at timestamp ts and
+	// reports whether that nonce has already been seen.
+	Seen(consumerKey, nonce string, ts int64) bool
+}
+
+// VerifyErrorCode classifies why Verifier.Verify rejected a request, so a
+// provider endpoint can map it to the appropriate HTTP 401 oauth_problem.
+type VerifyErrorCode int
+
+const (
+	// ErrMissingParameter means a required oauth_* parameter was absent or
+	// malformed.
+	ErrMissingParameter VerifyErrorCode = iota + 1
+	// ErrUnknownToken means TokenLookup rejected the consumer key or token.
+	ErrUnknownToken
+	// ErrBadSignature means the recomputed signature did not match.
+	ErrBadSignature
+	// ErrStaleTimestamp means oauth_timestamp fell outside the allowed skew.
+	ErrStaleTimestamp
+	// ErrReplayedNonce means the NonceStore had already seen this nonce.
+	ErrReplayedNonce
+)
+
+// VerifyError reports why an incoming request failed verification.
+type VerifyError struct {
+	Code VerifyErrorCode
+	Err  error
+}
+
+func (e *VerifyError) Error() string {
+	return "oauth1: " + e.Err.Error()
+}
+
+func (e *VerifyError) Unwrap() error {
+	return e.Err
+}
+
+// ParsedOAuthParams holds the OAuth1 protocol parameters extracted from a
+// request that passed Verifier.Verify.
+type ParsedOAuthParams struct {
+	ConsumerKey string
+	Token       string
+	Nonce       string
+	Timestamp   int64
+	Version     string
+	Callback    string
+	Verifier    string
+}
+
+// Verifier checks the signature of incoming OAuth1 requests, for building
+// provider endpoints (request-token issuer, access-token issuer, protected
+// resource) on top of the signing half of this package.
+type Verifier struct {
+	method SignatureMethod
+	lookup TokenLookup
+	nonces NonceStore
+	clock  clock
+
+	// MaxTimestampSkew bounds how far oauth_timestamp may drift from the
+	// current time before a request is rejected as stale. Zero disables the
+	// check, which is not recommended outside of tests.
+	MaxTimestampSkew time.Duration
+}
+
+// NewVerifier returns a Verifier which checks signatures using method,
+// resolving consumer and token secrets via lookup. The default
+// MaxTimestampSkew is 10 minutes; set it on the returned Verifier to change
+// it. Replay protection is disabled until SetNonceStore is called.
+func NewVerifier(method SignatureMethod, lookup TokenLookup) *Verifier {
+	return &Verifier{
+		method:           method,
+		lookup:           lookup,
+		clock:            newRealClock(),
+		MaxTimestampSkew: 10 * time.Minute,
+	}
+}
+
+// SetNonceStore enables replay protection using store.
+func (v *Verifier) SetNonceStore(store NonceStore) {
+	v.nonces = store
+}
+
+// Verify parses the OAuth1 protocol parameters from req (from the
+// Authorization header per RFC 5849 3.5.1, falling back to the request body
+// or query string per 3.5.2 and 3.5.3), reconstructs the signature base via
+// signatureBase/collectParameters, and checks the signature, any declared
+// oauth_body_hash, and the timestamp skew. The nonce is only recorded as
+// seen once the request is otherwise authenticated, so a forged or garbled
+// request cannot burn a nonce a legitimate client hasn't used yet. It
+// returns the parsed parameters on success, or a *VerifyError identifying
+// why the request was rejected.
+func (v *Verifier) Verify(req *http.Request) (*ParsedOAuthParams, error) {
+	oauthParams, viaHeader, err := parseOAuthParams(req)
+	if err != nil {
+		return nil, &VerifyError{Code: ErrMissingParameter, Err: err}
+	}
+	consumerKey := oauthParams[oauthConsumerKeyParam]
+	token := oauthParams[oauthTokenParam]
+	nonce := oauthParams[oauthNonceParam]
+	signature := oauthParams[oauthSignatureParam]
+	timestampStr := oauthParams[oauthTimestampParam]
+	if consumerKey == "" || nonce == "" || signature == "" || timestampStr == "" {
+		return nil, &VerifyError{Code: ErrMissingParameter, Err: errors.New("missing required oauth parameter")}
+	}
+	timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
+	if err != nil {
+		return nil, &VerifyError{Code: ErrMissingParameter, Err: errors.New("invalid oauth_timestamp")}
+	}
+	if v.MaxTimestampSkew > 0 {
+		skew := v.clock.Now().Sub(time.Unix(timestamp, 0))
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > v.MaxTimestampSkew {
+			return nil, &VerifyError{Code: ErrStaleTimestamp, Err: errors.New("oauth_timestamp outside allowed skew")}
+		}
+	}
+	consumerSecret, tokenSecret, err := v.lookup(consumerKey, token)
+	if err != nil {
+		return nil, &VerifyError{Code: ErrUnknownToken, Err: err}
+	}
+	method := v.method
+	if binder, ok := method.(secretBinder); ok {
+		method = binder.withConsumerSecret(consumerSecret)
+	}
+	if declared, ok := oauthParams[oauthBodyHashParam]; ok {
+		actual, err := bodyHashForRequest(req, method)
+		if err != nil {
+			return nil, &VerifyError{Code: ErrMissingParameter, Err: err}
+		}
+		if subtle.ConstantTimeCompare([]byte(declared), []byte(actual)) != 1 {
+			return nil, &VerifyError{Code: ErrBadSignature, Err: errors.New("oauth_body_hash does not match request body")}
+		}
+	}
+	// collectParameters already picks up the oauth_* parameters from the
+	// request query/body when that is how they were delivered (3.5.2/3.5.3),
+	// so they must only be merged in here when they arrived via the
+	// Authorization header instead, or they would be counted twice in the
+	// signature base.
+	signingParams := map[string]string{}
+	if viaHeader {
+		for key, value := range oauthParams {
+			if key != oauthSignatureParam {
+				signingParams[key] = value
+			}
+		}
+	}
+	params, err := collectParameters(req, signingParams)
+	if err != nil {
+		return nil, &VerifyError{Code: ErrMissingParameter, Err: err}
+	}
+	base := signatureBase(req, params)
+	if err := method.Verify(tokenSecret, base, signature); err != nil {
+		return nil, &VerifyError{Code: ErrBadSignature, Err: err}
+	}
+	if v.nonces != nil && v.nonces.Seen(consumerKey, nonce, timestamp) {
+		return nil, &VerifyError{Code: ErrReplayedNonce, Err: errors.New("oauth_nonce already used")}
+	}
+	return &ParsedOAuthParams{
+		ConsumerKey: consumerKey,
+		Token:       token,
+		Nonce:       nonce,
+		Timestamp:   timestamp,
+		Version:     oauthParams[oauthVersionParam],
+		Callback:    oauthParams[oauthCallbackParam],
+		Verifier:    oauthParams[oauthVerifierParam],
+	}, nil
+}
+
+// secretBinder lets Verifier substitute the consumer secret resolved by
+// TokenLookup into a SignatureMethod constructed once up front, so a single
+// Verifier can serve many consumers that share an HMAC-based method. RSA
+// methods do not implement this: the consumer secret is ignored for RSA, and
+// the method already holds the relevant key.
+type secretBinder interface {
+	withConsumerSecret(consumerSecret string) SignatureMethod
+}
+
+func (m *hmacSignatureMethod) withConsumerSecret(consumerSecret string) SignatureMethod {
+	return &hmacSignatureMethod{name: m.name, consumerSecret: consumerSecret, hash: m.hash}
+}
+
+func (m *plaintextSignatureMethod) withConsumerSecret(consumerSecret string) SignatureMethod {
+	return &plaintextSignatureMethod{consumerSecret: consumerSecret}
+}
+
+// parseOAuthParams extracts the oauth_* and xoauth_requestor_id parameters
+// from an incoming request's Authorization header per RFC 5849 3.5.1,
+// falling back to the request's form-encoded body or query string per 3.5.2
+// and 3.5.3 when no OAuth Authorization header is present. It reports
+// whether the parameters were delivered via the Authorization header, which
+// Verify needs to decide whether they must be merged back in when
+// reconstructing the signature base: when they fall back to the body or
+// query string, collectParameters already finds them there. When it falls
+// back to req.ParseForm, the body is buffered and restored on req.Body
+// afterward (req.ParseForm drains it and does not), mirroring
+// collectParameters' handling of form bodies, so Verify's later call to
+// collectParameters still sees the full body.
+func parseOAuthParams(req *http.Request) (params map[string]string, viaHeader bool, err error) {
+	params = map[string]string{}
+	if header := req.Header.Get(authorizationHeaderParam); strings.HasPrefix(header, authorizationPrefix) {
+		pairs := strings.Split(strings.TrimPrefix(header, authorizationPrefix), ",")
+		for _, pair := range pairs {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				return nil, false, errors.New("malformed Authorization header")
+			}
+			key, err := url.PathUnescape(kv[0])
+			if err != nil {
+				return nil, false, err
+			}
+			value, err := url.PathUnescape(strings.Trim(kv[1], `"`))
+			if err != nil {
+				return nil, false, err
+			}
+			if isOAuthParam(key) {
+				params[key] = value
+			}
+		}
+		return params, true, nil
+	}
+	hadBody := req.Body != nil
+	var bodyBytes []byte
+	if hadBody {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, false, err
+		}
+		bodyBytes = b
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	}
+	err = req.ParseForm()
+	if hadBody {
+		req.Body = ioutil.NopCloser(bytes.NewReader(bodyBytes))
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	for key, values := range req.Form {
+		if isOAuthParam(key) && len(values) > 0 {
+			params[key] = values[0]
+		}
+	}
+	return params, false, nil
+}
+
+// isOAuthParam reports whether key is one of the protocol parameters that
+// participate in the OAuth1 signature base.
+func isOAuthParam(key string) bool {
+	return strings.HasPrefix(key, "oauth_") || key == xoauthRequestorIDParam
+}
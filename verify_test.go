@@ -0,0 +1,253 @@
+package oauth1
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fixedClock struct{ t time.Time }
+
+func (f fixedClock) Now() time.Time { return f.t }
+
+type memoryNonceStore struct {
+	seen map[string]bool
+}
+
+func newMemoryNonceStore() *memoryNonceStore {
+	return &memoryNonceStore{seen: map[string]bool{}}
+}
+
+func (s *memoryNonceStore) Seen(consumerKey, nonce string, ts int64) bool {
+	key := consumerKey + "|" + nonce
+	if s.seen[key] {
+		return true
+	}
+	s.seen[key] = true
+	return false
+}
+
+func signedTestRequest(t *testing.T, cfg *Config, accessToken *Token, at time.Time) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest("GET", "http://example.com/resource?foo=bar", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := &Signer{config: cfg, clock: fixedClock{at}}
+	if err := signer.SetRequestAuthHeader(req, accessToken); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func assertVerifyErrorCode(t *testing.T, err error, want VerifyErrorCode) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("Verify() = nil error, want a *VerifyError with code %d", want)
+	}
+	verifyErr, ok := err.(*VerifyError)
+	if !ok {
+		t.Fatalf("Verify() error type = %T, want *VerifyError", err)
+	}
+	if verifyErr.Code != want {
+		t.Fatalf("Verify() error code = %d, want %d", verifyErr.Code, want)
+	}
+}
+
+func TestVerifierVerifySuccess(t *testing.T) {
+	cfg := &Config{ConsumerKey: "consumer-key", ConsumerSecret: "consumer-secret"}
+	accessToken := &Token{Token: "token", TokenSecret: "token-secret"}
+	now := time.Now()
+	req := signedTestRequest(t, cfg, accessToken, now)
+
+	v := NewVerifier(NewHMACSHA1Signer(""), func(consumerKey, token string) (string, string, error) {
+		return "consumer-secret", "token-secret", nil
+	})
+	v.clock = fixedClock{now}
+	parsed, err := v.Verify(req)
+	if err != nil {
+		t.Fatalf("Verify() = %v, want nil", err)
+	}
+	if parsed.ConsumerKey != "consumer-key" || parsed.Token != "token" {
+		t.Fatalf("unexpected parsed params: %+v", parsed)
+	}
+}
+
+func TestVerifierVerifyBadSignature(t *testing.T) {
+	cfg := &Config{ConsumerKey: "consumer-key", ConsumerSecret: "consumer-secret"}
+	accessToken := &Token{Token: "token", TokenSecret: "token-secret"}
+	now := time.Now()
+	req := signedTestRequest(t, cfg, accessToken, now)
+
+	v := NewVerifier(NewHMACSHA1Signer(""), func(consumerKey, token string) (string, string, error) {
+		return "wrong-consumer-secret", "token-secret", nil
+	})
+	v.clock = fixedClock{now}
+	_, err := v.Verify(req)
+	assertVerifyErrorCode(t, err, ErrBadSignature)
+}
+
+func TestVerifierVerifyUnknownToken(t *testing.T) {
+	cfg := &Config{ConsumerKey: "consumer-key", ConsumerSecret: "consumer-secret"}
+	accessToken := &Token{Token: "token", TokenSecret: "token-secret"}
+	now := time.Now()
+	req := signedTestRequest(t, cfg, accessToken, now)
+
+	v := NewVerifier(NewHMACSHA1Signer(""), func(consumerKey, token string) (string, string, error) {
+		return "", "", errors.New("unknown token")
+	})
+	v.clock = fixedClock{now}
+	_, err := v.Verify(req)
+	assertVerifyErrorCode(t, err, ErrUnknownToken)
+}
+
+func TestVerifierVerifyStaleTimestamp(t *testing.T) {
+	cfg := &Config{ConsumerKey: "consumer-key", ConsumerSecret: "consumer-secret"}
+	accessToken := &Token{Token: "token", TokenSecret: "token-secret"}
+	signedAt := time.Now().Add(-1 * time.Hour)
+	req := signedTestRequest(t, cfg, accessToken, signedAt)
+
+	v := NewVerifier(NewHMACSHA1Signer(""), func(consumerKey, token string) (string, string, error) {
+		return "consumer-secret", "token-secret", nil
+	})
+	v.clock = fixedClock{time.Now()}
+	_, err := v.Verify(req)
+	assertVerifyErrorCode(t, err, ErrStaleTimestamp)
+}
+
+func TestVerifierVerifyReplayedNonce(t *testing.T) {
+	cfg := &Config{ConsumerKey: "consumer-key", ConsumerSecret: "consumer-secret"}
+	accessToken := &Token{Token: "token", TokenSecret: "token-secret"}
+	now := time.Now()
+	req := signedTestRequest(t, cfg, accessToken, now)
+
+	v := NewVerifier(NewHMACSHA1Signer(""), func(consumerKey, token string) (string, string, error) {
+		return "consumer-secret", "token-secret", nil
+	})
+	v.clock = fixedClock{now}
+	v.SetNonceStore(newMemoryNonceStore())
+
+	if _, err := v.Verify(req); err != nil {
+		t.Fatalf("first Verify() = %v, want nil", err)
+	}
+	_, err := v.Verify(req)
+	assertVerifyErrorCode(t, err, ErrReplayedNonce)
+}
+
+// TestVerifierVerifyDoesNotBurnNonceOnForgedRequest guards against a forged
+// or garbled request (wrong signature) consuming a nonce before it has been
+// authenticated, which would let an attacker who doesn't know the secret
+// cause a legitimate client's correctly-signed request to be rejected as a
+// replay.
+func TestVerifierVerifyDoesNotBurnNonceOnForgedRequest(t *testing.T) {
+	cfg := &Config{ConsumerKey: "consumer-key", ConsumerSecret: "consumer-secret"}
+	accessToken := &Token{Token: "token", TokenSecret: "token-secret"}
+	now := time.Now()
+	req := signedTestRequest(t, cfg, accessToken, now)
+
+	store := newMemoryNonceStore()
+
+	forger := NewVerifier(NewHMACSHA1Signer(""), func(consumerKey, token string) (string, string, error) {
+		return "wrong-consumer-secret", "token-secret", nil
+	})
+	forger.clock = fixedClock{now}
+	forger.SetNonceStore(store)
+	if _, err := forger.Verify(req); err == nil {
+		t.Fatal("Verify() with the wrong consumer secret = nil error, want error")
+	}
+
+	legitimate := NewVerifier(NewHMACSHA1Signer(""), func(consumerKey, token string) (string, string, error) {
+		return "consumer-secret", "token-secret", nil
+	})
+	legitimate.clock = fixedClock{now}
+	legitimate.SetNonceStore(store)
+	if _, err := legitimate.Verify(req); err != nil {
+		t.Fatalf("Verify() of the legitimate request after a forged attempt with the same nonce = %v, want nil", err)
+	}
+}
+
+// TestVerifierVerifyFormEncodedBodyDelivery guards against a request that
+// delivers its oauth_* parameters via the form-encoded body (RFC 5849 3.5.2)
+// rather than the Authorization header, alongside an ordinary non-OAuth form
+// field: that field must still contribute to the reconstructed signature
+// base, and the oauth_* parameters (already present in the body) must not
+// also be double counted by being merged back in a second time.
+func TestVerifierVerifyFormEncodedBodyDelivery(t *testing.T) {
+	cfg := &Config{ConsumerKey: "consumer-key", ConsumerSecret: "consumer-secret"}
+	accessToken := &Token{Token: "token", TokenSecret: "token-secret"}
+	now := time.Now()
+
+	signer := &Signer{config: cfg, clock: fixedClock{now}}
+	oauthParams := signer.commonOAuthParams()
+	oauthParams[oauthTokenParam] = accessToken.Token
+
+	signReq, err := http.NewRequest("POST", "http://example.com/resource", strings.NewReader(url.Values{"foo": {"bar"}}.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signReq.Header.Set(contentType, formContentType)
+	params, err := collectParameters(signReq, oauthParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := signatureBase(signReq, params)
+	signature, err := cfg.signatureMethod().Sign(accessToken.TokenSecret, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oauthParams[oauthSignatureParam] = signature
+
+	wireValues := url.Values{"foo": {"bar"}}
+	for key, value := range oauthParams {
+		wireValues.Set(key, value)
+	}
+	req, err := http.NewRequest("POST", "http://example.com/resource", strings.NewReader(wireValues.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(contentType, formContentType)
+
+	v := NewVerifier(NewHMACSHA1Signer(""), func(consumerKey, token string) (string, string, error) {
+		return "consumer-secret", "token-secret", nil
+	})
+	v.clock = fixedClock{now}
+	if _, err := v.Verify(req); err != nil {
+		t.Fatalf("Verify() = %v, want nil", err)
+	}
+}
+
+// TestVerifierVerifyRejectsTamperedBody guards against a party that alters
+// the wire body without re-signing (e.g. a tampering proxy): the declared
+// oauth_body_hash must be checked against the actual request body, not just
+// trusted as self-consistent with the signature.
+func TestVerifierVerifyRejectsTamperedBody(t *testing.T) {
+	cfg := &Config{ConsumerKey: "consumer-key", ConsumerSecret: "consumer-secret", IncludeBodyHash: true}
+	accessToken := &Token{Token: "token", TokenSecret: "token-secret"}
+	now := time.Now()
+
+	req, err := http.NewRequest("POST", "http://example.com/resource", bytes.NewReader([]byte(`{"a":1}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(contentType, "application/json")
+	signer := &Signer{config: cfg, clock: fixedClock{now}}
+	if err := signer.SetRequestAuthHeader(req, accessToken); err != nil {
+		t.Fatal(err)
+	}
+
+	// A tampering proxy swaps the body after signing without updating the
+	// Authorization header or its declared oauth_body_hash.
+	req.Body = ioutil.NopCloser(bytes.NewReader([]byte(`{"a":2}`)))
+
+	v := NewVerifier(NewHMACSHA1Signer(""), func(consumerKey, token string) (string, string, error) {
+		return "consumer-secret", "token-secret", nil
+	})
+	v.clock = fixedClock{now}
+	_, err = v.Verify(req)
+	assertVerifyErrorCode(t, err, ErrBadSignature)
+}